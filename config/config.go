@@ -0,0 +1,91 @@
+// Package config reads server settings from flags and environment
+// variables so deployments aren't stuck with the old hard-coded
+// localhost:8080/SQLite setup.
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds every setting the server needs to start. Each field is
+// read from a flag, falling back to an env var, falling back to a
+// default.
+type Config struct {
+	Addr            string
+	LogLevel        string
+	DBDriver        string
+	DBDSN           string
+	AuthDBDSN       string
+	CORSOrigins     []string
+	CookieDomain    string
+	CookieSecure    bool
+	ShutdownTimeout time.Duration
+}
+
+// Load parses flags (and the flag package's command-line args) into a
+// Config. It calls flag.Parse, so it should run once, early in main.
+func Load() Config {
+	addr := flag.String("addr", envOr("ADDR", "localhost:8080"), "address to listen on")
+	logLevel := flag.String("log-level", envOr("LOG_LEVEL", "info"), "log level: debug, info, warn, or error")
+	dbDriver := flag.String("db-driver", envOr("DB_DRIVER", "sqlite3"), "signal store driver: sqlite3 or postgres")
+	// _foreign_keys=true turns on SQLite's FK enforcement (off by
+	// default per-connection); the signal.user_id -> users.id FK relies
+	// on it. Operators overriding either DSN must keep this param or
+	// that FK silently stops being enforced.
+	dbDSN := flag.String(
+		"db-dsn",
+		envOr("DB_DSN", "file:signals.db?mode=rwc&cache=shared&_locking_mode=EXCLUSIVE&_sync=FULL&_foreign_keys=true"),
+		"signal store connection string",
+	)
+	authDBDSN := flag.String(
+		"auth-db-dsn",
+		envOr("AUTH_DB_DSN", "file:signals.db?mode=rwc&cache=shared&_locking_mode=EXCLUSIVE&_sync=FULL&_foreign_keys=true"),
+		"SQLite connection string for users/sessions; only used when db-driver is postgres, since the sqlite3 signal store shares its pool",
+	)
+	corsOrigins := flag.String("cors-origins", envOr("CORS_ORIGINS", ""), "comma-separated list of allowed CORS origins")
+	cookieDomain := flag.String("cookie-domain", envOr("COOKIE_DOMAIN", ""), "domain for the session cookie")
+	cookieSecure := flag.Bool("cookie-secure", envOr("COOKIE_SECURE", "") == "true", "mark the session cookie Secure")
+	shutdownTimeout := flag.Duration(
+		"shutdown-timeout",
+		envDurationOr("SHUTDOWN_TIMEOUT", 5*time.Second),
+		"graceful shutdown timeout",
+	)
+
+	flag.Parse()
+
+	var origins []string
+	if *corsOrigins != "" {
+		origins = strings.Split(*corsOrigins, ",")
+	}
+
+	return Config{
+		Addr:            *addr,
+		LogLevel:        *logLevel,
+		DBDriver:        *dbDriver,
+		DBDSN:           *dbDSN,
+		AuthDBDSN:       *authDBDSN,
+		CORSOrigins:     origins,
+		CookieDomain:    *cookieDomain,
+		CookieSecure:    *cookieSecure,
+		ShutdownTimeout: *shutdownTimeout,
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envDurationOr(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
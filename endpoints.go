@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+
+	// maxTopTagsCacheEntries bounds the cache so a flood of distinct
+	// ?window= values on this public, unauthenticated endpoint can't
+	// grow it without bound.
+	maxTopTagsCacheEntries = 1000
+
+	// windowQuantum buckets the requested window to the minute, so
+	// windows that differ only by seconds (e.g. "3599s" vs "1h") share a
+	// cache entry and the key space stays small.
+	windowQuantum = time.Minute
+)
+
+var topTagsCache = newTTLCache(30*time.Second, maxTopTagsCacheEntries)
+
+type tagCountA struct {
+	Tag string `json:"tag"`
+	Net int32  `json:"net"`
+}
+
+type topTagsQ struct {
+	Window string `form:"window"`
+	Limit  int    `form:"limit"`
+}
+
+// topTags handles GET /v1/tags/top?window=7d&limit=50: tags with the
+// largest net signal count across all URLs in the given time window.
+// Results are cached briefly since this scans the whole signal table.
+func topTags(c *gin.Context) {
+	var q topTagsQ
+	if err := c.BindQuery(&q); err != nil {
+		return
+	}
+
+	window := 7 * 24 * time.Hour
+	if q.Window != "" {
+		w, err := parseWindow(q.Window)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = w
+	}
+	window = window.Round(windowQuantum)
+	limit := clampLimit(q.Limit)
+
+	cacheKey := fmt.Sprintf("%s:%d", window, limit)
+	if cached, ok := topTagsCache.get(cacheKey); ok {
+		c.IndentedJSON(http.StatusOK, cached)
+		return
+	}
+
+	tags, err := signals.TopTags(c.Request.Context(), window, limit)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	out := make([]tagCountA, 0, len(tags))
+	for _, t := range tags {
+		out = append(out, tagCountA{Tag: t.Tag, Net: t.Net})
+	}
+	resp := gin.H{"tags": out}
+	topTagsCache.set(cacheKey, resp)
+	c.IndentedJSON(http.StatusOK, resp)
+}
+
+// parseWindow accepts Go duration strings plus a bare day suffix (e.g.
+// "7d"), since that's the unit callers actually want for a trending
+// window.
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+type topUrlsQ struct {
+	Tag   string `form:"tag" binding:"required"`
+	Limit int    `form:"limit"`
+}
+
+type urlCountA struct {
+	Url string `json:"url"`
+	Net int32  `json:"net"`
+}
+
+// topUrls handles GET /v1/urls?tag=foo: URLs most strongly signaled for
+// a tag, most positive net signal first.
+func topUrls(c *gin.Context) {
+	var q topUrlsQ
+	if err := c.BindQuery(&q); err != nil {
+		return
+	}
+
+	urls, err := signals.TopURLs(c.Request.Context(), q.Tag, clampLimit(q.Limit))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	out := make([]urlCountA, 0, len(urls))
+	for _, u := range urls {
+		out = append(out, urlCountA{Url: u.Url, Net: u.Net})
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"urls": out})
+}
+
+type mySignalsQ struct {
+	Since string `form:"since"`
+	Limit int    `form:"limit"`
+}
+
+type userSignalA struct {
+	Url       string    `json:"url"`
+	Tag       string    `json:"tag"`
+	Signal    bool      `json:"signal"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// myUserSignals handles GET /v1/users/me/signals?since=...: the
+// authenticated user's own signal history, newest first.
+func myUserSignals(c *gin.Context) {
+	uid := c.MustGet("userId").(int64)
+
+	var q mySignalsQ
+	if err := c.BindQuery(&q); err != nil {
+		return
+	}
+
+	var since time.Time
+	if q.Since != "" {
+		t, err := time.Parse(time.RFC3339, q.Since)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "since must be RFC3339"})
+			return
+		}
+		since = t
+	}
+
+	sigs, err := signals.UserSignals(c.Request.Context(), uid, since, clampLimit(q.Limit))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	out := make([]userSignalA, 0, len(sigs))
+	for _, s := range sigs {
+		out = append(out, userSignalA{Url: s.Url, Tag: s.Tag, Signal: s.Signal, UpdatedAt: s.UpdatedAt})
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"signals": out})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"24h", 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"d", 0, true},
+		{"xd", 0, true},
+		{"not-a-duration", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseWindow(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseWindow(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			}
+			if err == nil && got != tc.want {
+				t.Errorf("parseWindow(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClampLimit(t *testing.T) {
+	cases := []struct {
+		in   int
+		want int
+	}{
+		{0, defaultLimit},
+		{-5, defaultLimit},
+		{1, 1},
+		{maxLimit, maxLimit},
+		{maxLimit + 1, maxLimit},
+	}
+
+	for _, tc := range cases {
+		if got := clampLimit(tc.in); got != tc.want {
+			t.Errorf("clampLimit(%d) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
@@ -3,16 +3,23 @@ package main
 import (
 	"context"
 	"database/sql"
-	"github.com/gin-gonic/gin"
-	_ "github.com/mattn/go-sqlite3"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/FicAI/ficai-signals-server/config"
+	"github.com/FicAI/ficai-signals-server/migrations"
+	"github.com/FicAI/ficai-signals-server/store"
 )
 
 var db *sql.DB
+var signals store.Store
+var cfg config.Config
 
 type getQ struct {
 	Url string `form:"url"`
@@ -37,154 +44,136 @@ type patchQ struct {
 }
 
 func getSignals(c *gin.Context) {
-	uid, err := c.Cookie("FicAiUid")
-	if err != nil {
-		c.AbortWithError(http.StatusForbidden, err)
-		return
-	}
+	uid := c.MustGet("userId").(int64)
 
 	var q getQ
 	if err := c.BindQuery(&q); err != nil {
 		return
 	}
-	rows, err := db.Query(
-		`
-select
-	tag,
-	sum(iif(signal, 1, 0)) as total_for,
-    sum(iif(not signal, 1, 0)) as total_against,
-    sum(signal) filter (where user_id = ?) as my_signal
-from signal
-where url = ?
-group by tag
-`,
-		uid, q.Url,
-	)
+
+	tags, err := signals.GetSignals(c.Request.Context(), uid, q.Url)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-	defer rows.Close()
-
-	tags := make([]getTagInfo, 0)
-	for rows.Next() {
-		var (
-			tag           string
-			total_for     int32
-			total_against int32
-			my_signal     sql.NullBool
-		)
-		if err := rows.Scan(&tag, &total_for, &total_against, &my_signal); err != nil {
-			c.AbortWithError(http.StatusInternalServerError, err)
-			return
-		}
-		tagInfo := getTagInfo{
-			Tag:            tag,
-			Signal:         nil,
-			SignalsFor:     total_for,
-			SignalsAgainst: total_against,
-		}
-		if my_signal.Valid {
-			tagInfo.Signal = &my_signal.Bool
-		}
-		tags = append(tags, tagInfo)
+
+	apiTags := make([]getTagInfo, 0, len(tags))
+	for _, t := range tags {
+		apiTags = append(apiTags, getTagInfo{
+			Tag:            t.Tag,
+			Signal:         t.Signal,
+			SignalsFor:     t.SignalsFor,
+			SignalsAgainst: t.SignalsAgainst,
+		})
 	}
-	c.IndentedJSON(http.StatusOK, getA{tags})
+	c.IndentedJSON(http.StatusOK, getA{apiTags})
 }
 
 func patchSignals(c *gin.Context) {
-	uid, err := c.Cookie("FicAiUid")
-	if err != nil {
-		c.AbortWithError(http.StatusForbidden, err)
-		return
-	}
+	uid := c.MustGet("userId").(int64)
 
 	var q patchQ
 	if err := c.BindJSON(&q); err != nil {
 		return
 	}
 
-	log.Printf("'%s' %v\n", uid, q)
-
-	if q.Add != nil {
-		for _, tag := range q.Add {
-			if _, err := db.Exec(
-				"insert or replace into signal (user_id, url, tag, signal) values (?, ?, ?, ?)",
-				uid,
-				q.Url,
-				tag,
-				true,
-			); err != nil {
-				c.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-		}
-	}
-	if q.Rm != nil {
-		for _, tag := range q.Rm {
-			if _, err := db.Exec(
-				"insert or replace into signal (user_id, url, tag, signal) values (?, ?, ?, ?)",
-				uid,
-				q.Url,
-				tag,
-				false,
-			); err != nil {
-				c.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-		}
+	logger.Debug("patch request", slog.Int64("userId", uid), slog.Any("body", q))
+
+	if err := validatePatchQ(q); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if q.Erase != nil {
-		for _, tag := range q.Erase {
-			if _, err := db.Exec(
-				"delete from signal where user_id = ? and url = ? and tag = ?",
-				uid,
-				q.Url,
-				tag,
-			); err != nil {
-				c.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-		}
+
+	patch := store.Patch{UserId: uid, Url: q.Url, Add: q.Add, Rm: q.Rm, Erase: q.Erase}
+	if err := signals.PatchSignals(c.Request.Context(), patch); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
 	}
 
 	c.AbortWithStatus(http.StatusNoContent)
 }
 
 func main() {
+	cfg = config.Load()
+	initLogger(cfg.LogLevel)
+
 	var err error
 
-	db, err = sql.Open("sqlite3", "file:signals.db?mode=rwc&cache=shared&_locking_mode=EXCLUSIVE&_sync=FULL")
+	// Users and sessions are SQLite-only today: their queries use
+	// SQLite placeholder syntax and their unique-constraint check
+	// matches the SQLite driver's error type. When the signal store is
+	// also SQLite, auth shares its connection pool/file instead of
+	// opening a second pool against the same _locking_mode=EXCLUSIVE
+	// database; when the signal store is Postgres, auth keeps its own
+	// SQLite database via cfg.AuthDBDSN.
+	if cfg.DBDriver == "sqlite3" {
+		db, err = sql.Open("sqlite3", cfg.DBDSN)
+	} else {
+		db, err = sql.Open("sqlite3", cfg.AuthDBDSN)
+	}
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("opening database", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer db.Close()
 
-	router := gin.Default()
-	router.GET("/v1/signals", getSignals)
-	router.PATCH("/v1/signals", patchSignals)
+	if err := migrations.Run(db, "sqlite3", "auth"); err != nil {
+		logger.Error("running migrations", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if cfg.DBDriver == "sqlite3" {
+		signals, err = store.NewSQLiteStoreFromDB(db)
+	} else {
+		signals, err = store.Open(cfg.DBDriver, cfg.DBDSN)
+	}
+	if err != nil {
+		logger.Error("opening signal store", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer signals.Close()
+
+	router := gin.New()
+	router.Use(gin.Recovery(), requestLogger())
+	if len(cfg.CORSOrigins) > 0 {
+		router.Use(cors.New(cors.Config{
+			AllowOrigins:     cfg.CORSOrigins,
+			AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE"},
+			AllowHeaders:     []string{"Content-Type"},
+			AllowCredentials: true,
+		}))
+	}
+	router.POST("/v1/users", registerUser)
+	router.POST("/v1/sessions", login)
+	router.DELETE("/v1/sessions", logout)
+	router.GET("/v1/signals", requireSession, getSignals)
+	router.PATCH("/v1/signals", requireSession, patchSignals)
+	router.GET("/v1/tags/top", topTags)
+	router.GET("/v1/urls", topUrls)
+	router.GET("/v1/users/me/signals", requireSession, myUserSignals)
 
 	srv := &http.Server{
-		Addr:    "localhost:8080",
+		Addr:    cfg.Addr,
 		Handler: router,
 	}
 
 	go func() {
 		// service connections
-		if err := srv.ListenAndServe(); err != nil {
-			log.Printf("listen: %s\n", err)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("listen", slog.Any("error", err))
 		}
 	}()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
-	log.Println("shutting down server")
+	logger.Info("shutting down server")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server Shutdown:", err)
+		logger.Error("server shutdown", slog.Any("error", err))
+		os.Exit(1)
 	}
-	log.Println("Server exiting")
+	logger.Info("server exiting")
 }
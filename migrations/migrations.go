@@ -0,0 +1,137 @@
+// Package migrations applies the embedded SQL files under sql/ to a
+// database on startup, recording which versions have already run in
+// that database's schema_migrations table so re-running Run is a
+// no-op. The SQL lives in per-domain, per-driver subdirectories (e.g.
+// "auth" or "signal/postgres") since the app's data is split across
+// more than one database and driver; see Run.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed sql/auth/*.sql sql/signal/sqlite/*.sql sql/signal/postgres/*.sql
+var sqlFS embed.FS
+
+// dir is part of schema_migrations' primary key (alongside version)
+// because a single database can end up running more than one dir's
+// migrations — e.g. the default setup applies both "auth" and
+// "signal/sqlite" against one shared SQLite file, and their version
+// numbers each start at 1 independently.
+const createSchemaMigrationsTable = `
+create table if not exists schema_migrations (
+	dir text not null,
+	version integer not null,
+	applied_at timestamp not null default current_timestamp,
+	primary key (dir, version)
+)`
+
+// Run applies any migration under the embedded subdirectory "sql/<dir>"
+// (named "<version>_<name>.sql") that isn't yet recorded in db's
+// schema_migrations table, in version order, each in its own
+// transaction. driver selects the bind-parameter syntax ("sqlite3" or
+// "postgres") used for Run's own bookkeeping queries; it does not
+// affect the migration files themselves, which are executed verbatim.
+func Run(db *sql.DB, driver, dir string) error {
+	if _, err := db.Exec(createSchemaMigrationsTable); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+
+	root := "sql/" + dir
+	entries, err := sqlFS.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("reading embedded migrations %s: %w", root, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		vi, _ := versionFromName(names[i])
+		vj, _ := versionFromName(names[j])
+		return vi < vj
+	})
+
+	for _, name := range names {
+		if err := applyIfPending(db, driver, root, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyIfPending(db *sql.DB, driver, root, name string) error {
+	version, err := versionFromName(name)
+	if err != nil {
+		return err
+	}
+
+	var applied bool
+	if err := db.QueryRow(
+		fmt.Sprintf(
+			"select exists(select 1 from schema_migrations where dir = %s and version = %s)",
+			placeholder(driver, 1), placeholder(driver, 2),
+		),
+		root, version,
+	).Scan(&applied); err != nil {
+		return fmt.Errorf("checking migration %s/%s: %w", root, name, err)
+	}
+	if applied {
+		return nil
+	}
+
+	contents, err := sqlFS.ReadFile(root + "/" + name)
+	if err != nil {
+		return fmt.Errorf("reading migration %s/%s: %w", root, name, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(string(contents)); err != nil {
+		return fmt.Errorf("applying migration %s/%s: %w", root, name, err)
+	}
+	if _, err := tx.Exec(
+		fmt.Sprintf(
+			"insert into schema_migrations (dir, version) values (%s, %s)",
+			placeholder(driver, 1), placeholder(driver, 2),
+		),
+		root, version,
+	); err != nil {
+		return fmt.Errorf("recording migration %s/%s: %w", root, name, err)
+	}
+
+	return tx.Commit()
+}
+
+// placeholder returns driver's bind-parameter syntax for the nth
+// argument in a query (sqlite3 uses "?"; postgres uses "$n").
+func placeholder(driver string, n int) string {
+	if driver == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func versionFromName(name string) (int, error) {
+	i := strings.IndexByte(name, '_')
+	if i < 0 {
+		return 0, fmt.Errorf("migration filename %q missing version prefix", name)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(name[:i], "%d", &version); err != nil {
+		return 0, fmt.Errorf("migration filename %q has invalid version prefix: %w", name, err)
+	}
+	return version, nil
+}
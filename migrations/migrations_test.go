@@ -0,0 +1,30 @@
+package migrations
+
+import "testing"
+
+func TestVersionFromName(t *testing.T) {
+	cases := []struct {
+		name    string
+		want    int
+		wantErr bool
+	}{
+		{"0001_signal.sql", 1, false},
+		{"0002_users_sessions.sql", 2, false},
+		{"10_no_leading_zero.sql", 10, false},
+		{"missing_version.sql", 0, true},
+		{"abc_bad_version.sql", 0, true},
+		{"noseparator.sql", 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := versionFromName(tc.name)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("versionFromName(%q) error = %v, wantErr %v", tc.name, err, tc.wantErr)
+			}
+			if err == nil && got != tc.want {
+				t.Errorf("versionFromName(%q) = %d, want %d", tc.name, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sessionCookie = "FicAiSession"
+const sessionTTL = 30 * 24 * time.Hour
+
+type loginQ struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+func login(c *gin.Context) {
+	var q loginQ
+	if err := c.BindJSON(&q); err != nil {
+		return
+	}
+
+	var userId int64
+	var hash string
+	err := db.QueryRow(
+		"select id, password_hash from users where email = ?",
+		q.Email,
+	).Scan(&userId, &hash)
+	if err == sql.ErrNoRows {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(q.Password)) != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(sessionTTL)
+	if _, err := db.Exec(
+		"insert into sessions (token, user_id, expires_at) values (?, ?, ?)",
+		token,
+		userId,
+		expiresAt,
+	); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.SetCookie(sessionCookie, token, int(sessionTTL.Seconds()), "/", cfg.CookieDomain, cfg.CookieSecure, true)
+	c.Status(http.StatusNoContent)
+}
+
+func logout(c *gin.Context) {
+	token, err := c.Cookie(sessionCookie)
+	if err != nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if _, err := db.Exec("delete from sessions where token = ?", token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.SetCookie(sessionCookie, "", -1, "/", cfg.CookieDomain, cfg.CookieSecure, true)
+	c.Status(http.StatusNoContent)
+}
+
+func newSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// requireSession resolves the session cookie to a user id and stores it
+// in the gin context as "userId" for downstream handlers, replacing the
+// old trust-the-cookie FicAiUid scheme.
+func requireSession(c *gin.Context) {
+	token, err := c.Cookie(sessionCookie)
+	if err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	}
+
+	var userId int64
+	var expiresAt time.Time
+	err = db.QueryRow(
+		"select user_id, expires_at from sessions where token = ?",
+		token,
+	).Scan(&userId, &expiresAt)
+	if err == sql.ErrNoRows {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	} else if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if time.Now().After(expiresAt) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	c.Set("userId", userId)
+	c.Next()
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/FicAI/ficai-signals-server/migrations"
+)
+
+// PostgresStore is a Store backed by Postgres. Unlike SQLiteStore it
+// allows concurrent writers, so it's the one to reach for once a single
+// SQLite file becomes a bottleneck.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrations.Run(db, "postgres", "signal/postgres"); err != nil {
+		return nil, fmt.Errorf("running signal schema migrations: %w", err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) Close() error { return s.db.Close() }
+
+func (s *PostgresStore) GetSignals(ctx context.Context, userId int64, url string) ([]TagInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+select
+	tag,
+	count(*) filter (where signal) as total_for,
+	count(*) filter (where not signal) as total_against,
+	bool_or(signal) filter (where user_id = $1) as my_signal
+from signal
+where url = $2
+group by tag
+`, userId, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]TagInfo, 0)
+	for rows.Next() {
+		var (
+			tag          string
+			totalFor     int32
+			totalAgainst int32
+			mySignal     sql.NullBool
+		)
+		if err := rows.Scan(&tag, &totalFor, &totalAgainst, &mySignal); err != nil {
+			return nil, err
+		}
+		info := TagInfo{Tag: tag, SignalsFor: totalFor, SignalsAgainst: totalAgainst}
+		if mySignal.Valid {
+			info.Signal = &mySignal.Bool
+		}
+		tags = append(tags, info)
+	}
+	return tags, rows.Err()
+}
+
+func (s *PostgresStore) PatchSignals(ctx context.Context, p Patch) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.PrepareContext(ctx, `
+insert into signal (user_id, url, tag, signal, created_at, updated_at)
+values ($1, $2, $3, $4, now(), now())
+on conflict (user_id, url, tag) do update set signal = excluded.signal, updated_at = now()
+`)
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+
+	for _, tag := range p.Add {
+		if _, err := upsert.ExecContext(ctx, p.UserId, p.Url, tag, true); err != nil {
+			return err
+		}
+	}
+	for _, tag := range p.Rm {
+		if _, err := upsert.ExecContext(ctx, p.UserId, p.Url, tag, false); err != nil {
+			return err
+		}
+	}
+
+	erase, err := tx.PrepareContext(ctx, "delete from signal where user_id = $1 and url = $2 and tag = $3")
+	if err != nil {
+		return err
+	}
+	defer erase.Close()
+
+	for _, tag := range p.Erase {
+		if _, err := erase.ExecContext(ctx, p.UserId, p.Url, tag); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) TopTags(ctx context.Context, window time.Duration, limit int) ([]TagCount, error) {
+	since := time.Now().Add(-window)
+
+	rows, err := s.db.QueryContext(ctx, `
+select
+	tag,
+	count(*) filter (where signal) - count(*) filter (where not signal) as net
+from signal
+where updated_at >= $1
+group by tag
+order by net desc
+limit $2
+`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Net); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+func (s *PostgresStore) TopURLs(ctx context.Context, tag string, limit int) ([]URLCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+select
+	url,
+	count(*) filter (where signal) - count(*) filter (where not signal) as net
+from signal
+where tag = $1
+group by url
+order by net desc
+limit $2
+`, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []URLCount
+	for rows.Next() {
+		var uc URLCount
+		if err := rows.Scan(&uc.Url, &uc.Net); err != nil {
+			return nil, err
+		}
+		urls = append(urls, uc)
+	}
+	return urls, rows.Err()
+}
+
+func (s *PostgresStore) UserSignals(ctx context.Context, userId int64, since time.Time, limit int) ([]UserSignal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+select url, tag, signal, updated_at
+from signal
+where user_id = $1 and updated_at >= $2
+order by updated_at desc
+limit $3
+`, userId, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sigs []UserSignal
+	for rows.Next() {
+		var s UserSignal
+		if err := rows.Scan(&s.Url, &s.Tag, &s.Signal, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, s)
+	}
+	return sigs, rows.Err()
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/FicAI/ficai-signals-server/migrations"
+)
+
+// SQLiteStore is a Store backed by SQLite. Note SQLite with
+// _locking_mode=EXCLUSIVE serializes all writes, so it won't scale past
+// a single writer; use PostgresStore for that.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLiteStoreFromDB(db)
+}
+
+// NewSQLiteStoreFromDB wraps an already-open *sql.DB instead of opening
+// a new connection pool, applying the signal schema migrations against
+// it. Used when the signal store shares its pool with the rest of the
+// app (the default setup, a single SQLite file for everything) so the
+// app doesn't end up with two independent pools against one
+// _locking_mode=EXCLUSIVE database.
+func NewSQLiteStoreFromDB(db *sql.DB) (*SQLiteStore, error) {
+	if err := migrations.Run(db, "sqlite3", "signal/sqlite"); err != nil {
+		return nil, fmt.Errorf("running signal schema migrations: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Close() error { return s.db.Close() }
+
+func (s *SQLiteStore) GetSignals(ctx context.Context, userId int64, url string) ([]TagInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+select
+	tag,
+	sum(iif(signal, 1, 0)) as total_for,
+    sum(iif(not signal, 1, 0)) as total_against,
+    sum(signal) filter (where user_id = ?) as my_signal
+from signal
+where url = ?
+group by tag
+`, userId, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]TagInfo, 0)
+	for rows.Next() {
+		var (
+			tag          string
+			totalFor     int32
+			totalAgainst int32
+			mySignal     sql.NullBool
+		)
+		if err := rows.Scan(&tag, &totalFor, &totalAgainst, &mySignal); err != nil {
+			return nil, err
+		}
+		info := TagInfo{Tag: tag, SignalsFor: totalFor, SignalsAgainst: totalAgainst}
+		if mySignal.Valid {
+			info.Signal = &mySignal.Bool
+		}
+		tags = append(tags, info)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLiteStore) PatchSignals(ctx context.Context, p Patch) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.PrepareContext(ctx, `
+insert into signal (user_id, url, tag, signal, created_at, updated_at)
+values (?, ?, ?, ?, current_timestamp, current_timestamp)
+on conflict (user_id, url, tag) do update set signal = excluded.signal, updated_at = current_timestamp
+`)
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+
+	for _, tag := range p.Add {
+		if _, err := upsert.ExecContext(ctx, p.UserId, p.Url, tag, true); err != nil {
+			return err
+		}
+	}
+	for _, tag := range p.Rm {
+		if _, err := upsert.ExecContext(ctx, p.UserId, p.Url, tag, false); err != nil {
+			return err
+		}
+	}
+
+	erase, err := tx.PrepareContext(ctx, "delete from signal where user_id = ? and url = ? and tag = ?")
+	if err != nil {
+		return err
+	}
+	defer erase.Close()
+
+	for _, tag := range p.Erase {
+		if _, err := erase.ExecContext(ctx, p.UserId, p.Url, tag); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) TopTags(ctx context.Context, window time.Duration, limit int) ([]TagCount, error) {
+	since := time.Now().Add(-window)
+
+	rows, err := s.db.QueryContext(ctx, `
+select
+	tag,
+	sum(iif(signal, 1, -1)) as net
+from signal
+where updated_at >= ?
+group by tag
+order by net desc
+limit ?
+`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Net); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLiteStore) TopURLs(ctx context.Context, tag string, limit int) ([]URLCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+select
+	url,
+	sum(iif(signal, 1, -1)) as net
+from signal
+where tag = ?
+group by url
+order by net desc
+limit ?
+`, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []URLCount
+	for rows.Next() {
+		var uc URLCount
+		if err := rows.Scan(&uc.Url, &uc.Net); err != nil {
+			return nil, err
+		}
+		urls = append(urls, uc)
+	}
+	return urls, rows.Err()
+}
+
+func (s *SQLiteStore) UserSignals(ctx context.Context, userId int64, since time.Time, limit int) ([]UserSignal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+select url, tag, signal, updated_at
+from signal
+where user_id = ? and updated_at >= ?
+order by updated_at desc
+limit ?
+`, userId, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sigs []UserSignal
+	for rows.Next() {
+		var s UserSignal
+		if err := rows.Scan(&s.Url, &s.Tag, &s.Signal, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, s)
+	}
+	return sigs, rows.Err()
+}
@@ -0,0 +1,74 @@
+// Package store abstracts access to the signal table behind an
+// interface so the server can run against either SQLite or Postgres.
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TagInfo is the per-tag aggregate returned for a single URL.
+type TagInfo struct {
+	Tag            string
+	Signal         *bool
+	SignalsFor     int32
+	SignalsAgainst int32
+}
+
+// Patch describes one PATCH /v1/signals request: tags to add, tags to
+// remove (signal false), and tags to erase entirely, all for a single
+// user and URL.
+type Patch struct {
+	UserId int64
+	Url    string
+	Add    []string
+	Rm     []string
+	Erase  []string
+}
+
+// TagCount is a tag's net signal count (for minus against) within some
+// time window.
+type TagCount struct {
+	Tag string
+	Net int32
+}
+
+// URLCount is a URL's net signal count (for minus against) for a given
+// tag.
+type URLCount struct {
+	Url string
+	Net int32
+}
+
+// UserSignal is one signal a user has set, as returned by a user's
+// history.
+type UserSignal struct {
+	Url       string
+	Tag       string
+	Signal    bool
+	UpdatedAt time.Time
+}
+
+// Store is everything the signal handlers need from the database.
+type Store interface {
+	GetSignals(ctx context.Context, userId int64, url string) ([]TagInfo, error)
+	PatchSignals(ctx context.Context, patch Patch) error
+	TopTags(ctx context.Context, window time.Duration, limit int) ([]TagCount, error)
+	TopURLs(ctx context.Context, tag string, limit int) ([]URLCount, error)
+	UserSignals(ctx context.Context, userId int64, since time.Time, limit int) ([]UserSignal, error)
+	Close() error
+}
+
+// Open opens a Store for the given driver ("sqlite3" or "postgres")
+// against dsn.
+func Open(driver, dsn string) (Store, error) {
+	switch driver {
+	case "sqlite3":
+		return NewSQLiteStore(dsn)
+	case "postgres":
+		return NewPostgresStore(dsn)
+	default:
+		return nil, fmt.Errorf("unknown db driver %q", driver)
+	}
+}
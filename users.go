@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type registerQ struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+func registerUser(c *gin.Context) {
+	var q registerQ
+	if err := c.BindJSON(&q); err != nil {
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(q.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err := db.Exec(
+		"insert into users (email, password_hash) values (?, ?)",
+		q.Email,
+		string(hash),
+	); err != nil {
+		if isUniqueConstraintErr(err) {
+			c.AbortWithStatus(http.StatusConflict)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusCreated)
+}
+
+func isUniqueConstraintErr(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint
+}
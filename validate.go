@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	maxTagLength = 64
+	maxBatchSize = 100
+)
+
+var errEmptyUrl = errors.New("url must not be empty")
+
+// validatePatchQ checks the shape of a patchQ before it touches the
+// database: a non-empty URL, tags within length/charset limits, a sane
+// total batch size, and no tag appearing in more than one of
+// add/rm/erase at once.
+func validatePatchQ(q patchQ) error {
+	if q.Url == "" {
+		return errEmptyUrl
+	}
+
+	total := len(q.Add) + len(q.Rm) + len(q.Erase)
+	if total > maxBatchSize {
+		return fmt.Errorf("batch of %d tags exceeds max of %d", total, maxBatchSize)
+	}
+
+	seen := make(map[string]string, total)
+	for _, group := range []struct {
+		name string
+		tags []string
+	}{
+		{"add", q.Add},
+		{"rm", q.Rm},
+		{"erase", q.Erase},
+	} {
+		for _, tag := range group.tags {
+			if err := validateTag(tag); err != nil {
+				return err
+			}
+			if other, ok := seen[tag]; ok && other != group.name {
+				return fmt.Errorf("tag %q present in both %q and %q", tag, other, group.name)
+			}
+			seen[tag] = group.name
+		}
+	}
+
+	return nil
+}
+
+func validateTag(tag string) error {
+	if tag == "" {
+		return errors.New("tag must not be empty")
+	}
+	if len(tag) > maxTagLength {
+		return fmt.Errorf("tag %q exceeds max length of %d", tag, maxTagLength)
+	}
+	for _, r := range tag {
+		if !isValidTagRune(r) {
+			return fmt.Errorf("tag %q contains invalid character %q", tag, r)
+		}
+	}
+	return nil
+}
+
+func isValidTagRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return true
+	case r >= '0' && r <= '9':
+		return true
+	case r == '-' || r == '_' || r == ' ':
+		return true
+	default:
+		return false
+	}
+}
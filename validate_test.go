@@ -0,0 +1,93 @@
+package main
+
+import "testing"
+
+func TestValidatePatchQ(t *testing.T) {
+	cases := []struct {
+		name    string
+		q       patchQ
+		wantErr bool
+	}{
+		{"valid", patchQ{Url: "http://example.com", Add: []string{"fluff"}, Rm: []string{"angst"}}, false},
+		{"empty url", patchQ{Url: "", Add: []string{"fluff"}}, true},
+		{"batch too large", patchQ{Url: "http://example.com", Add: make([]string, maxBatchSize+1)}, true},
+		{"invalid tag", patchQ{Url: "http://example.com", Add: []string{"Not Valid!"}}, true},
+		{"tag in add and rm", patchQ{Url: "http://example.com", Add: []string{"fluff"}, Rm: []string{"fluff"}}, true},
+		{"tag in add and erase", patchQ{Url: "http://example.com", Add: []string{"fluff"}, Erase: []string{"fluff"}}, true},
+		{"same tag same group twice is fine", patchQ{Url: "http://example.com", Add: []string{"fluff", "fluff"}}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// batch-too-large uses a slice of empty strings; give them a
+			// valid tag so the size check, not the charset check, fires.
+			if tc.name == "batch too large" {
+				for i := range tc.q.Add {
+					tc.q.Add[i] = "fluff"
+				}
+			}
+
+			err := validatePatchQ(tc.q)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validatePatchQ(%+v) error = %v, wantErr %v", tc.q, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	cases := []struct {
+		name    string
+		tag     string
+		wantErr bool
+	}{
+		{"empty", "", true},
+		{"simple", "fluff", false},
+		{"digits and dash and underscore", "fluff-123_ok", false},
+		{"space allowed", "slow burn", false},
+		{"uppercase rejected", "Fluff", true},
+		{"too long", string(make([]byte, maxTagLength+1)), true},
+		{"exactly max length", string(makeRuneString('a', maxTagLength)), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTag(tc.tag)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateTag(%q) error = %v, wantErr %v", tc.tag, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsValidTagRune(t *testing.T) {
+	cases := []struct {
+		r    rune
+		want bool
+	}{
+		{'a', true},
+		{'z', true},
+		{'0', true},
+		{'9', true},
+		{'-', true},
+		{'_', true},
+		{' ', true},
+		{'A', false},
+		{'!', false},
+		{'é', false},
+	}
+
+	for _, tc := range cases {
+		if got := isValidTagRune(tc.r); got != tc.want {
+			t.Errorf("isValidTagRune(%q) = %v, want %v", tc.r, got, tc.want)
+		}
+	}
+}
+
+func makeRuneString(r rune, n int) []rune {
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = r
+	}
+	return out
+}